@@ -3,26 +3,89 @@ package sqlm
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
+	"errors"
 	"fmt"
+	"math"
 	"reflect"
+	"strconv"
+	"time"
 )
 
+// ErrConversion is returned when a value can not be converted to the requested type.
+var ErrConversion = errors.New("conversion failed")
+
+// timeLayouts lists the layouts used to parse a textual value as time.Time.
+var timeLayouts = []string{
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+	time.RFC3339Nano,
+}
+
 // Any is map of string containing any values.
 type Any map[string]any
 
+// Has returns true if the key exists, even if its value is nil, as a SQL NULL value.
+func (m Any) Has(key string) bool {
+	_, ok := m[key]
+	return ok
+}
+
+// Bool returns the value behind this key as a boolean.
+// If it's a nil pointer, we return false instead.
+func (m Any) Bool(key string) (bool, error) {
+	v, err := toBool(m[key])
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", key, err)
+	}
+	return v, nil
+}
+
+// Bytes returns the value behind this key as a slice of bytes.
+// If it's a nil pointer, we return a nil slice instead.
+func (m Any) Bytes(key string) ([]byte, error) {
+	v, err := toBytes(m[key])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", key, err)
+	}
+	return v, nil
+}
+
+// Float returns the value behind this key as a float64.
+// If it's a nil pointer, we return 0 instead.
+func (m Any) Float(key string) (float64, error) {
+	v, err := toFloat(m[key])
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return v, nil
+}
+
+// Int returns the value behind this key as an int64.
+// If it's a nil pointer, we return 0 instead.
+func (m Any) Int(key string) (int64, error) {
+	v, err := toInt(m[key])
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", key, err)
+	}
+	return v, nil
+}
+
+// Time returns the value behind this key as a time.Time.
+// Textual values are parsed as DATETIME, DATE or RFC 3339 layouts.
+// If it's a nil pointer, we return the zero time instead.
+func (m Any) Time(key string) (time.Time, error) {
+	v, err := toTime(m[key])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s: %w", key, err)
+	}
+	return v, nil
+}
+
 // String returns the string representation of the value behind this key.
 // If it's a nil pointer, we return a blank string instead.
 func (m Any) String(key string) string {
-	switch v := m[key].(type) {
-	case nil:
-		return ""
-	case fmt.Stringer:
-		return v.String()
-	case string:
-		return v
-	default:
-		return fmt.Sprintf("%v", v)
-	}
+	return toString(m[key])
 }
 
 // QueryAny executes a query that is expected to return at most one row, so one Any.
@@ -48,51 +111,320 @@ func QueryAnyRows(ctx context.Context, conn Tx, query string, args ...any) ([]An
 }
 
 func queryAnyRows(ctx context.Context, conn Tx, query string, single bool, args []any) ([]Any, error) {
-	rows, err := conn.QueryContext(ctx, query, args...)
+	it, err := QueryAnyIter(ctx, conn, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("query executing: %w", err)
+		return nil, err
 	}
-	defer func() { _ = rows.Close() }()
+	defer func() { _ = it.Close() }()
 
-	cols, err := rows.ColumnTypes()
-	if err != nil {
-		return nil, fmt.Errorf("columns describing: %w", err)
-	}
 	var res []Any
-	for rows.Next() {
-		rs := makeRs(cols)
-		err = rows.Scan(rs...)
-		if err != nil {
-			return nil, fmt.Errorf("rows scanning: %w", err)
-		}
-		res = append(res, makeAny(cols, rs))
+	for it.Next() {
+		res = append(res, it.Scan())
 		if single {
 			break
 		}
 	}
-	err = rows.Close()
+	err = it.Close()
 	if err != nil {
-		return nil, fmt.Errorf("rows closing: %w", err)
+		return nil, err
 	}
-	err = rows.Err()
+	err = it.Err()
 	if err != nil {
-		return nil, fmt.Errorf("rows iterating: %w", err)
+		return nil, err
 	}
 	return res, nil
 }
 
+// makeAny builds the Any of a row scanned with makeRs.
+// A SQL NULL value is stored as nil, any other as a concrete value.
 func makeAny(cols []*sql.ColumnType, values []any) Any {
 	res := make(Any, len(cols))
 	for pos, col := range cols {
-		res[col.Name()] = values[pos]
+		res[col.Name()] = deref(col, values[pos])
 	}
 	return res
 }
 
+func deref(col *sql.ColumnType, v any) any {
+	p := reflect.ValueOf(v).Elem()
+	if p.IsNil() {
+		return nil
+	}
+	v = p.Elem().Interface()
+	if col.ScanType().Implements(valuerType) {
+		// Unwraps the sql.Null* types used by drivers for nullable columns.
+		if dv, err := v.(driver.Valuer).Value(); err == nil {
+			return dv
+		}
+	}
+	return v
+}
+
+// makeRs returns the scan destinations of a row, one pointer to a pointer of
+// the column scan type, so a SQL NULL value can be differentiated from a zero one.
 func makeRs(cols []*sql.ColumnType) []any {
 	res := make([]any, len(cols))
 	for pos, col := range cols {
-		res[pos] = reflect.Zero(col.ScanType())
+		t := col.ScanType()
+		if t == rawBytesType {
+			// RawBytes are only valid until the next call to Next, so uses bytes.
+			t = bytesType
+		}
+		res[pos] = reflect.New(reflect.PointerTo(t)).Interface()
 	}
 	return res
 }
+
+var (
+	bytesType    = reflect.TypeOf([]byte(nil))
+	rawBytesType = reflect.TypeOf(sql.RawBytes(nil))
+	valuerType   = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+)
+
+func toString(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case fmt.Stringer:
+		return v.String()
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case sql.RawBytes:
+		return string(v)
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+func toBool(v any) (bool, error) {
+	switch v := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return v, nil
+	case int, int8, int16, int32, int64:
+		i, err := toInt(v)
+		return i != 0, err
+	case uint, uint8, uint16, uint32, uint64:
+		u, err := toUint(v)
+		return u != 0, err
+	case []byte:
+		return parseBool(string(v))
+	case sql.RawBytes:
+		return parseBool(string(v))
+	case string:
+		return parseBool(v)
+	case driver.Valuer:
+		dv, err := v.Value()
+		if err != nil {
+			return false, fmt.Errorf("%w: %s", ErrConversion, err)
+		}
+		return toBool(dv)
+	default:
+		return false, conversionError(v, "bool")
+	}
+}
+
+func toBytes(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case nil:
+		return nil, nil
+	case []byte:
+		return v, nil
+	case sql.RawBytes:
+		// RawBytes may be reused by the driver, so we make a copy.
+		return append([]byte(nil), v...), nil
+	case string:
+		return []byte(v), nil
+	case driver.Valuer:
+		dv, err := v.Value()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrConversion, err)
+		}
+		return toBytes(dv)
+	default:
+		return nil, conversionError(v, "[]byte")
+	}
+}
+
+func toFloat(v any) (float64, error) {
+	switch v := v.(type) {
+	case nil:
+		return 0, nil
+	case float32:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case int, int8, int16, int32, int64:
+		i, err := toInt(v)
+		return float64(i), err
+	case uint, uint8, uint16, uint32, uint64:
+		u, err := toUint(v)
+		return float64(u), err
+	case []byte:
+		return parseFloat(string(v))
+	case sql.RawBytes:
+		return parseFloat(string(v))
+	case string:
+		return parseFloat(v)
+	case driver.Valuer:
+		dv, err := v.Value()
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s", ErrConversion, err)
+		}
+		return toFloat(dv)
+	default:
+		return 0, conversionError(v, "float64")
+	}
+}
+
+func toInt(v any) (int64, error) {
+	switch v := v.(type) {
+	case nil:
+		return 0, nil
+	case int:
+		return int64(v), nil
+	case int8:
+		return int64(v), nil
+	case int16:
+		return int64(v), nil
+	case int32:
+		return int64(v), nil
+	case int64:
+		return v, nil
+	case uint:
+		return toInt(uint64(v))
+	case uint8:
+		return int64(v), nil
+	case uint16:
+		return int64(v), nil
+	case uint32:
+		return int64(v), nil
+	case uint64:
+		if v > math.MaxInt64 {
+			return 0, fmt.Errorf("%w: %d overflows int64", ErrConversion, v)
+		}
+		return int64(v), nil
+	case []byte:
+		return parseInt(string(v))
+	case sql.RawBytes:
+		return parseInt(string(v))
+	case string:
+		return parseInt(v)
+	case driver.Valuer:
+		dv, err := v.Value()
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s", ErrConversion, err)
+		}
+		return toInt(dv)
+	default:
+		return 0, conversionError(v, "int64")
+	}
+}
+
+func toTime(v any) (time.Time, error) {
+	switch v := v.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return v, nil
+	case []byte:
+		return parseTime(string(v))
+	case sql.RawBytes:
+		return parseTime(string(v))
+	case string:
+		return parseTime(v)
+	case driver.Valuer:
+		dv, err := v.Value()
+		if err != nil {
+			return time.Time{}, fmt.Errorf("%w: %s", ErrConversion, err)
+		}
+		return toTime(dv)
+	default:
+		return time.Time{}, conversionError(v, "time.Time")
+	}
+}
+
+func toUint(v any) (uint64, error) {
+	switch v := v.(type) {
+	case uint:
+		return uint64(v), nil
+	case uint8:
+		return uint64(v), nil
+	case uint16:
+		return uint64(v), nil
+	case uint32:
+		return uint64(v), nil
+	case uint64:
+		return v, nil
+	case []byte:
+		return parseUint(string(v))
+	case sql.RawBytes:
+		return parseUint(string(v))
+	case string:
+		return parseUint(v)
+	case driver.Valuer:
+		dv, err := v.Value()
+		if err != nil {
+			return 0, fmt.Errorf("%w: %s", ErrConversion, err)
+		}
+		return toUint(dv)
+	default:
+		i, err := toInt(v)
+		if err != nil {
+			return 0, conversionError(v, "uint64")
+		}
+		if i < 0 {
+			return 0, fmt.Errorf("%w: %d overflows uint64", ErrConversion, i)
+		}
+		return uint64(i), nil
+	}
+}
+
+func parseBool(s string) (bool, error) {
+	v, err := strconv.ParseBool(s)
+	if err != nil {
+		return false, fmt.Errorf("%w: %s", ErrConversion, err)
+	}
+	return v, nil
+}
+
+func parseFloat(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrConversion, err)
+	}
+	return v, nil
+}
+
+func parseInt(s string) (int64, error) {
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrConversion, err)
+	}
+	return v, nil
+}
+
+func parseUint(s string) (uint64, error) {
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s", ErrConversion, err)
+	}
+	return v, nil
+}
+
+func parseTime(s string) (time.Time, error) {
+	for _, layout := range timeLayouts {
+		v, err := time.Parse(layout, s)
+		if err == nil {
+			return v, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: %q does not match any time layout", ErrConversion, s)
+}
+
+func conversionError(v any, to string) error {
+	return fmt.Errorf("%w: %T to %s", ErrConversion, v, to)
+}
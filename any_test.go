@@ -0,0 +1,270 @@
+package sqlm_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/rvflash/sqlm"
+)
+
+func TestAny_Has(t *testing.T) {
+	m := sqlm.Any{"null": nil, "zero": 0}
+	for key, exp := range map[string]bool{"null": true, "zero": true, "missing": false} {
+		if got := m.Has(key); got != exp {
+			t.Errorf("Has(%q): got %v, expected %v", key, got, exp)
+		}
+	}
+}
+
+func TestAny_Int(t *testing.T) {
+	for name, tc := range map[string]struct {
+		in  any
+		out int64
+		err error
+	}{
+		"Missing":  {},
+		"Nil":      {in: nil},
+		"Int":      {in: -1, out: -1},
+		"Int8":     {in: int8(math.MinInt8), out: math.MinInt8},
+		"Int16":    {in: int16(math.MaxInt16), out: math.MaxInt16},
+		"Int32":    {in: int32(math.MinInt32), out: math.MinInt32},
+		"Int64":    {in: int64(math.MaxInt64), out: math.MaxInt64},
+		"Uint":     {in: uint(1), out: 1},
+		"Uint8":    {in: uint8(math.MaxUint8), out: math.MaxUint8},
+		"Uint16":   {in: uint16(math.MaxUint16), out: math.MaxUint16},
+		"Uint32":   {in: uint32(math.MaxUint32), out: math.MaxUint32},
+		"Uint64":   {in: uint64(math.MaxInt64), out: math.MaxInt64},
+		"Overflow": {in: uint64(math.MaxUint64), err: sqlm.ErrConversion},
+		"Bytes":    {in: []byte("42"), out: 42},
+		"RawBytes": {in: sql.RawBytes("-42"), out: -42},
+		"String":   {in: "7", out: 7},
+		"NaN":      {in: "seven", err: sqlm.ErrConversion},
+		"Float":    {in: 1.5, err: sqlm.ErrConversion},
+		"Valid":    {in: sql.NullInt64{Int64: 5, Valid: true}, out: 5},
+		"Invalid":  {in: sql.NullInt64{Int64: 5}},
+		"Int32Nul": {in: sql.NullInt32{Int32: 3, Valid: true}, out: 3},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			m := sqlm.Any{}
+			if name != "Missing" {
+				m["k"] = tc.in
+			}
+			out, err := m.Int("k")
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("unexpected error: got %v, expected %v", err, tc.err)
+			}
+			if out != tc.out {
+				t.Errorf("got %d, expected %d", out, tc.out)
+			}
+		})
+	}
+}
+
+func TestAny_Float(t *testing.T) {
+	for name, tc := range map[string]struct {
+		in  any
+		out float64
+		err error
+	}{
+		"Nil":      {},
+		"Float32":  {in: float32(1.5), out: 1.5},
+		"Float64":  {in: 2.25, out: 2.25},
+		"Int":      {in: 3, out: 3},
+		"Uint8":    {in: uint8(4), out: 4},
+		"Overflow": {in: uint64(1 << 63), out: 1 << 63},
+		"Bytes":    {in: []byte("1.25"), out: 1.25},
+		"RawBytes": {in: sql.RawBytes("-0.5"), out: -0.5},
+		"String":   {in: "10", out: 10},
+		"NaN":      {in: "ten", err: sqlm.ErrConversion},
+		"Bool":     {in: true, err: sqlm.ErrConversion},
+		"Valid":    {in: sql.NullFloat64{Float64: 0.75, Valid: true}, out: 0.75},
+		"Invalid":  {in: sql.NullFloat64{Float64: 0.75}},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			out, err := sqlm.Any{"k": tc.in}.Float("k")
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("unexpected error: got %v, expected %v", err, tc.err)
+			}
+			if out != tc.out {
+				t.Errorf("got %v, expected %v", out, tc.out)
+			}
+		})
+	}
+}
+
+func TestAny_Bool(t *testing.T) {
+	for name, tc := range map[string]struct {
+		in  any
+		out bool
+		err error
+	}{
+		"Nil":      {},
+		"Bool":     {in: true, out: true},
+		"Int64":    {in: int64(1), out: true},
+		"Zero":     {in: int8(0)},
+		"Uint":     {in: uint(0)},
+		"Overflow": {in: uint64(1 << 63), out: true},
+		"Bytes":    {in: []byte("1"), out: true},
+		"Raw":      {in: sql.RawBytes("false")},
+		"String":   {in: "true", out: true},
+		"Invalid":  {in: "yes", err: sqlm.ErrConversion},
+		"Float":    {in: 1.0, err: sqlm.ErrConversion},
+		"Valid":    {in: sql.NullBool{Bool: true, Valid: true}, out: true},
+		"Null":     {in: sql.NullBool{Bool: true}},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			out, err := sqlm.Any{"k": tc.in}.Bool("k")
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("unexpected error: got %v, expected %v", err, tc.err)
+			}
+			if out != tc.out {
+				t.Errorf("got %v, expected %v", out, tc.out)
+			}
+		})
+	}
+}
+
+func TestAny_Time(t *testing.T) {
+	var (
+		dt = time.Date(2023, 3, 4, 5, 6, 7, 0, time.UTC)
+		d  = time.Date(2023, 3, 4, 0, 0, 0, 0, time.UTC)
+	)
+	for name, tc := range map[string]struct {
+		in  any
+		out time.Time
+		err error
+	}{
+		"Nil":      {},
+		"Time":     {in: dt, out: dt},
+		"DateTime": {in: []byte("2023-03-04 05:06:07"), out: dt},
+		"Date":     {in: sql.RawBytes("2023-03-04"), out: d},
+		"RFC3339":  {in: "2023-03-04T05:06:07Z", out: dt},
+		"Invalid":  {in: "yesterday", err: sqlm.ErrConversion},
+		"Int":      {in: 1, err: sqlm.ErrConversion},
+		"Valid":    {in: sql.NullTime{Time: dt, Valid: true}, out: dt},
+		"Null":     {in: sql.NullTime{Time: dt}},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			out, err := sqlm.Any{"k": tc.in}.Time("k")
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("unexpected error: got %v, expected %v", err, tc.err)
+			}
+			if !out.Equal(tc.out) {
+				t.Errorf("got %v, expected %v", out, tc.out)
+			}
+		})
+	}
+}
+
+func TestAny_Bytes(t *testing.T) {
+	for name, tc := range map[string]struct {
+		in  any
+		out []byte
+		err error
+	}{
+		"Nil":      {},
+		"Bytes":    {in: []byte("a"), out: []byte("a")},
+		"RawBytes": {in: sql.RawBytes("b"), out: []byte("b")},
+		"String":   {in: "c", out: []byte("c")},
+		"Valid":    {in: sql.NullString{String: "d", Valid: true}, out: []byte("d")},
+		"Null":     {in: sql.NullString{String: "d"}},
+		"Int":      {in: 1, err: sqlm.ErrConversion},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			out, err := sqlm.Any{"k": tc.in}.Bytes("k")
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("unexpected error: got %v, expected %v", err, tc.err)
+			}
+			if string(out) != string(tc.out) || (out == nil) != (tc.out == nil) {
+				t.Errorf("got %q, expected %q", out, tc.out)
+			}
+		})
+	}
+}
+
+func TestAny_Bytes_RawBytesCopy(t *testing.T) {
+	raw := sql.RawBytes("abc")
+	out, err := sqlm.Any{"k": raw}.Bytes("k")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	raw[0] = 'z'
+	if string(out) != "abc" {
+		t.Errorf("got %q, expected a copy of the raw bytes", out)
+	}
+}
+
+func TestAny_String(t *testing.T) {
+	m := sqlm.Any{"null": nil, "int": 5, "str": "s", "bytes": []byte("bob"), "raw": sql.RawBytes("raw")}
+	for key, exp := range map[string]string{
+		"null": "", "missing": "", "int": "5", "str": "s", "bytes": "bob", "raw": "raw",
+	} {
+		if got := m.String(key); got != exp {
+			t.Errorf("String(%q): got %q, expected %q", key, got, exp)
+		}
+	}
+}
+
+func TestQueryAnyRows_Null(t *testing.T) {
+	var (
+		yes = true
+		no  = false
+	)
+	db, _ := newFakeDB(t, fakeResult{
+		columns: []fakeColumn{
+			{name: "i", scanType: reflect.TypeOf(int64(0)), nullable: &no},
+			{name: "s", scanType: reflect.TypeOf(""), nullable: &yes},
+			{name: "ni", scanType: reflect.TypeOf(sql.NullInt64{}), nullable: &yes},
+			{name: "ns", scanType: reflect.TypeOf(sql.NullString{})},
+			{name: "raw", scanType: reflect.TypeOf(sql.RawBytes{}), nullable: &yes},
+			{name: "any"},
+		},
+		values: [][]driver.Value{
+			{int64(0), "", int64(0), "", []byte{}, int64(0)},
+			{nil, nil, nil, nil, nil, nil},
+			{int64(1), "a", int64(2), "b", []byte("c"), "d"},
+		},
+	})
+	res, err := sqlm.QueryAnyRows(context.Background(), db, "SELECT")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := []sqlm.Any{
+		{"i": int64(0), "s": "", "ni": int64(0), "ns": "", "raw": []byte{}, "any": int64(0)},
+		{"i": nil, "s": nil, "ni": nil, "ns": nil, "raw": nil, "any": nil},
+		{"i": int64(1), "s": "a", "ni": int64(2), "ns": "b", "raw": []byte("c"), "any": "d"},
+	}
+	if !reflect.DeepEqual(res, exp) {
+		t.Fatalf("got %#v, expected %#v", res, exp)
+	}
+	for key := range exp[1] {
+		if !res[1].Has(key) {
+			t.Errorf("%s: NULL column must be present", key)
+		}
+		if res[1].String(key) != "" {
+			t.Errorf("%s: NULL column must be a blank string, got %q", key, res[1].String(key))
+		}
+	}
+	if got := res[2].String("ns"); got != "b" {
+		t.Errorf("got %q, expected the unwrapped sql.NullString", got)
+	}
+}
+
+func TestQueryAny_NoRows(t *testing.T) {
+	db, _ := newFakeDB(t, fakeResult{columns: []fakeColumn{{name: "id"}}})
+	_, err := sqlm.QueryAny(context.Background(), db, "SELECT")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("unexpected error: got %v, expected %v", err, sql.ErrNoRows)
+	}
+}
@@ -0,0 +1,191 @@
+package sqlm_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+var errNotSupported = errors.New("not supported")
+
+// fakeColumn describes a column of a fakeResult.
+// If nullable is nil, the nullability is not reported by the driver.
+type fakeColumn struct {
+	name     string
+	scanType reflect.Type
+	nullable *bool
+}
+
+// fakeResult is the result set returned by any query, with err returned after the last row.
+type fakeResult struct {
+	columns []fakeColumn
+	values  [][]driver.Value
+	err     error
+}
+
+// fakeDB is a database/sql driver recording the statements and transactions it receives.
+type fakeDB struct {
+	mu      sync.Mutex
+	log     []string
+	txOpts  []driver.TxOptions
+	result  fakeResult
+	closed  int
+	execErr error
+}
+
+func newFakeDB(t *testing.T, res fakeResult) (*sql.DB, *fakeDB) {
+	t.Helper()
+	f := &fakeDB{result: res}
+	db := sql.OpenDB(f)
+	t.Cleanup(func() { _ = db.Close() })
+	return db, f
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) {
+	return &fakeConn{db: f}, nil
+}
+
+func (f *fakeDB) Driver() driver.Driver {
+	return fakeDriver{}
+}
+
+// Log returns the statements received.
+func (f *fakeDB) Log() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.log...)
+}
+
+// TxOptions returns the options of each transaction started.
+func (f *fakeDB) TxOptions() []driver.TxOptions {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]driver.TxOptions(nil), f.txOpts...)
+}
+
+// Closed returns the number of result sets closed.
+func (f *fakeDB) Closed() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+func (f *fakeDB) record(query string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.log = append(f.log, query)
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(string) (driver.Conn, error) {
+	return nil, errNotSupported
+}
+
+type fakeConn struct {
+	db *fakeDB
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errNotSupported
+}
+
+// CheckNamedValue accepts any argument, as drivers handling slices natively.
+func (c *fakeConn) CheckNamedValue(*driver.NamedValue) error {
+	return nil
+}
+
+func (c *fakeConn) Close() error {
+	return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(_ context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	c.db.mu.Lock()
+	c.db.txOpts = append(c.db.txOpts, opts)
+	c.db.mu.Unlock()
+	c.db.record("BEGIN")
+	return &fakeTx{db: c.db}, nil
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Result, error) {
+	c.db.record(query)
+	if c.db.execErr != nil {
+		return nil, c.db.execErr
+	}
+	return driver.RowsAffected(0), nil
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, _ []driver.NamedValue) (driver.Rows, error) {
+	c.db.record(query)
+	return &fakeRows{db: c.db, res: c.db.result}, nil
+}
+
+type fakeTx struct {
+	db *fakeDB
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.db.record("COMMIT")
+	return nil
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.db.record("ROLLBACK")
+	return nil
+}
+
+type fakeRows struct {
+	db  *fakeDB
+	res fakeResult
+	pos int
+}
+
+func (r *fakeRows) Columns() []string {
+	res := make([]string, len(r.res.columns))
+	for pos, col := range r.res.columns {
+		res[pos] = col.name
+	}
+	return res
+}
+
+func (r *fakeRows) Close() error {
+	r.db.mu.Lock()
+	defer r.db.mu.Unlock()
+	r.db.closed++
+	return nil
+}
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.pos >= len(r.res.values) {
+		if r.res.err != nil {
+			return r.res.err
+		}
+		return io.EOF
+	}
+	copy(dest, r.res.values[r.pos])
+	r.pos++
+	return nil
+}
+
+func (r *fakeRows) ColumnTypeScanType(index int) reflect.Type {
+	if t := r.res.columns[index].scanType; t != nil {
+		return t
+	}
+	return reflect.TypeOf(new(any)).Elem()
+}
+
+func (r *fakeRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	if p := r.res.columns[index].nullable; p != nil {
+		return *p, true
+	}
+	return false, false
+}
@@ -0,0 +1,171 @@
+package sqlm
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrEmptySlice is returned when a slice argument to expand is empty.
+var ErrEmptySlice = errors.New("empty slice argument")
+
+// ExpandIn replaces the placeholder of each slice argument by as many placeholders
+// as elements in the slice, and flattens them in the returned arguments.
+// It allows to use a slice with an IN clause, e.g. "id IN (?)" with []int{1, 2}
+// becomes "id IN (?,?)" with 1, 2 as arguments.
+// Slices of bytes and values implementing driver.Valuer are not expanded.
+// Placeholders inside quoted strings, identifiers or comments are ignored.
+// An empty slice returns ErrEmptySlice since "IN ()" is not a valid SQL.
+// It only suits dialects using "?" as placeholder, such as MySQL or SQLite.
+func ExpandIn(query string, args ...any) (string, []any, error) {
+	if !hasSlice(args) {
+		return query, args, nil
+	}
+	var (
+		buf     strings.Builder
+		res     = make([]any, 0, len(args))
+		pos     int
+		quote   byte
+		esc     bool
+		comment byte
+	)
+	buf.Grow(len(query))
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case esc:
+			esc = false
+		case comment == '-':
+			if c == '\n' {
+				comment = 0
+			}
+		case comment == '*':
+			if c == '*' && next(query, i) == '/' {
+				buf.WriteByte(c)
+				i++
+				c = query[i]
+				comment = 0
+			}
+		case quote != 0:
+			switch c {
+			case '\\':
+				esc = quote != '`'
+			case quote:
+				// A doubled quote is seen as closing then reopening the string.
+				quote = 0
+			}
+		case c == '\'' || c == '"' || c == '`':
+			quote = c
+		case c == '#':
+			comment = '-'
+		case c == '-' && next(query, i) == '-' && isCommentSpace(next(query, i+1)):
+			comment = '-'
+		case c == '/' && next(query, i) == '*':
+			buf.WriteByte(c)
+			i++
+			c = query[i]
+			comment = '*'
+		case c == '?' && pos < len(args):
+			arg := args[pos]
+			pos++
+			if !isSlice(arg) {
+				res = append(res, arg)
+				break
+			}
+			rv := reflect.ValueOf(arg)
+			if rv.Len() == 0 {
+				return "", nil, fmt.Errorf("%w: argument #%d", ErrEmptySlice, pos)
+			}
+			for k := 0; k < rv.Len(); k++ {
+				if k > 0 {
+					buf.WriteString(",?")
+				} else {
+					buf.WriteByte('?')
+				}
+				res = append(res, rv.Index(k).Interface())
+			}
+			continue
+		}
+		buf.WriteByte(c)
+	}
+	return buf.String(), append(res, args[pos:]...), nil
+}
+
+// ExpandInTx returns a Tx expanding the slice arguments of each query with ExpandIn
+// before passing it to conn, so they can be used with any function of the package.
+// It's an opt-in since it only suits dialects using "?" as placeholder:
+// drivers handling slices natively or using "?" as operator must not use it.
+// As QueryRowContext can not return an error, it sends the query as is on failure.
+// The returned Tx can not be given to WithNestedTx, wrap the transaction instead.
+func ExpandInTx(conn Tx) Tx {
+	return &expandInTx{Tx: conn}
+}
+
+type expandInTx struct {
+	Tx
+}
+
+// ExecContext implements the Tx interface.
+func (tx *expandInTx) ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	query, args, err := ExpandIn(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query expanding: %w", err)
+	}
+	return tx.Tx.ExecContext(ctx, query, args...)
+}
+
+// QueryContext implements the Tx interface.
+func (tx *expandInTx) QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	query, args, err := ExpandIn(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query expanding: %w", err)
+	}
+	return tx.Tx.QueryContext(ctx, query, args...)
+}
+
+// QueryRowContext implements the Tx interface.
+func (tx *expandInTx) QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row {
+	if q, a, err := ExpandIn(query, args...); err == nil {
+		query, args = q, a
+	}
+	return tx.Tx.QueryRowContext(ctx, query, args...)
+}
+
+// next returns the byte following the position i in s or 0 if there is none.
+func next(s string, i int) byte {
+	if i+1 < len(s) {
+		return s[i+1]
+	}
+	return 0
+}
+
+// isCommentSpace returns true if c ends a "--" comment opening, as a whitespace or the end of the query.
+func isCommentSpace(c byte) bool {
+	switch c {
+	case 0, ' ', '\t', '\n', '\r':
+		return true
+	}
+	return false
+}
+
+func hasSlice(args []any) bool {
+	for _, arg := range args {
+		if isSlice(arg) {
+			return true
+		}
+	}
+	return false
+}
+
+func isSlice(arg any) bool {
+	switch arg.(type) {
+	case nil, driver.Valuer:
+		return false
+	}
+	t := reflect.TypeOf(arg)
+	return t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8
+}
@@ -0,0 +1,167 @@
+package sqlm_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/rvflash/sqlm"
+)
+
+// csv is a slice handled by the driver as one value.
+type csv []string
+
+func (c csv) Value() (driver.Value, error) {
+	return strings.Join(c, ","), nil
+}
+
+func TestExpandIn(t *testing.T) {
+	for name, tc := range map[string]struct {
+		query string
+		args  []any
+		out   string
+		outs  []any
+		err   error
+	}{
+		"NoSlice": {
+			query: "SELECT * FROM t WHERE a = ? AND b = ?",
+			args:  []any{1, "b"},
+			out:   "SELECT * FROM t WHERE a = ? AND b = ?",
+			outs:  []any{1, "b"},
+		},
+		"Slice": {
+			query: "SELECT * FROM t WHERE id IN (?)",
+			args:  []any{[]int{1, 2, 3}},
+			out:   "SELECT * FROM t WHERE id IN (?,?,?)",
+			outs:  []any{1, 2, 3},
+		},
+		"Mixed": {
+			query: "a = ? AND b IN (?) AND c = ? AND d IN (?)",
+			args:  []any{"a", []string{"b1", "b2"}, 3, []int64{4}},
+			out:   "a = ? AND b IN (?,?) AND c = ? AND d IN (?)",
+			outs:  []any{"a", "b1", "b2", 3, int64(4)},
+		},
+		"Quoted": {
+			query: `a = '?' AND b = "?" AND c IN (?)`,
+			args:  []any{[]int{1, 2}},
+			out:   `a = '?' AND b = "?" AND c IN (?,?)`,
+			outs:  []any{1, 2},
+		},
+		"DoubledQuote": {
+			query: "a = 'it''s ?' AND b IN (?)",
+			args:  []any{[]int{1, 2}},
+			out:   "a = 'it''s ?' AND b IN (?,?)",
+			outs:  []any{1, 2},
+		},
+		"Escaped": {
+			query: `a = 'it\'s ?' AND b = "\"?" AND c IN (?)`,
+			args:  []any{[]int{1, 2}},
+			out:   `a = 'it\'s ?' AND b = "\"?" AND c IN (?,?)`,
+			outs:  []any{1, 2},
+		},
+		"Backtick": {
+			query: "SELECT `a?\\` FROM t WHERE b IN (?)",
+			args:  []any{[]int{1, 2}},
+			out:   "SELECT `a?\\` FROM t WHERE b IN (?,?)",
+			outs:  []any{1, 2},
+		},
+		"LineComment": {
+			query: "SELECT * FROM t -- what?\n WHERE id IN (?)",
+			args:  []any{[]int{1, 2}},
+			out:   "SELECT * FROM t -- what?\n WHERE id IN (?,?)",
+			outs:  []any{1, 2},
+		},
+		"HashComment": {
+			query: "SELECT * FROM t # what?\n WHERE id IN (?)",
+			args:  []any{[]int{1, 2}},
+			out:   "SELECT * FROM t # what?\n WHERE id IN (?,?)",
+			outs:  []any{1, 2},
+		},
+		"BlockComment": {
+			query: "SELECT /* what? */ * FROM t WHERE id IN (?) /*/ ? */",
+			args:  []any{[]int{1, 2}},
+			out:   "SELECT /* what? */ * FROM t WHERE id IN (?,?) /*/ ? */",
+			outs:  []any{1, 2},
+		},
+		"Minus": {
+			query: "a = 1--? AND b IN (?)",
+			args:  []any{2, []int{3, 4}},
+			out:   "a = 1--? AND b IN (?,?)",
+			outs:  []any{2, 3, 4},
+		},
+		"Bytes": {
+			query: "a = ? AND b IN (?)",
+			args:  []any{[]byte("a"), []int{1, 2}},
+			out:   "a = ? AND b IN (?,?)",
+			outs:  []any{[]byte("a"), 1, 2},
+		},
+		"Valuer": {
+			query: "a = ? AND b IN (?)",
+			args:  []any{csv{"a", "b"}, []int{1, 2}},
+			out:   "a = ? AND b IN (?,?)",
+			outs:  []any{csv{"a", "b"}, 1, 2},
+		},
+		"ExtraArgs": {
+			query: "a IN (?)",
+			args:  []any{[]int{1, 2}, 3},
+			out:   "a IN (?,?)",
+			outs:  []any{1, 2, 3},
+		},
+		"Empty": {
+			query: "a IN (?)",
+			args:  []any{[]int{}},
+			err:   sqlm.ErrEmptySlice,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			out, outs, err := sqlm.ExpandIn(tc.query, tc.args...)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("unexpected error: got %v, expected %v", err, tc.err)
+			}
+			if out != tc.out {
+				t.Errorf("query mismatch: got %q, expected %q", out, tc.out)
+			}
+			if !reflect.DeepEqual(outs, tc.outs) {
+				t.Errorf("args mismatch: got %#v, expected %#v", outs, tc.outs)
+			}
+		})
+	}
+}
+
+func TestExpandInTx(t *testing.T) {
+	db, fake := newFakeDB(t, fakeResult{columns: []fakeColumn{{name: "id"}}})
+	ctx := context.Background()
+	conn := sqlm.ExpandInTx(db)
+	_, err := sqlm.QueryAnyRows(ctx, conn, "SELECT id FROM t WHERE id IN (?)", []int{1, 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = sqlm.ExecAndRowsAffected(ctx, conn, "DELETE FROM t WHERE id IN (?)", []int{1, 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_, err = sqlm.QueryAnyRows(ctx, conn, "SELECT id FROM t WHERE id IN (?)", []int{})
+	if !errors.Is(err, sqlm.ErrEmptySlice) {
+		t.Fatalf("unexpected error: got %v, expected %v", err, sqlm.ErrEmptySlice)
+	}
+	exp := []string{"SELECT id FROM t WHERE id IN (?,?)", "DELETE FROM t WHERE id IN (?,?)"}
+	if got := fake.Log(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("log mismatch: got %q, expected %q", got, exp)
+	}
+}
+
+func TestQueryAnyRows_NativeSlice(t *testing.T) {
+	db, fake := newFakeDB(t, fakeResult{columns: []fakeColumn{{name: "id"}}})
+	const query = "SELECT id FROM t WHERE data ? 'k' AND id = ANY($1)"
+	_, err := sqlm.QueryAnyRows(context.Background(), db, query, []int{1, 2})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, exp := fake.Log(), []string{query}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("the query must be sent as is: got %q, expected %q", got, exp)
+	}
+}
@@ -2,4 +2,4 @@ module github.com/rvflash/sqlm
 
 go 1.20
 
-require github.com/go-sql-driver/mysql v1.7.0 // indirect
+require github.com/go-sql-driver/mysql v1.7.0
@@ -0,0 +1,92 @@
+package sqlm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// AnyIter is an iterator over the rows of a query, each row is returned as Any.
+// The underlying rows keep a connection until Close is called.
+// Close is safe to call multiple times and is automatically called
+// once Next has returned false, with all the rows consumed or on error.
+// An AnyIter is not safe for concurrent use.
+type AnyIter struct {
+	rows   *sql.Rows
+	cols   []*sql.ColumnType
+	cur    Any
+	err    error
+	closed bool
+}
+
+// QueryAnyIter executes a query that returns rows, typically a SELECT,
+// and returns an iterator to read them one at a time, without buffering.
+// The args are for any placeholder parameters in the query.
+// The caller must call Close if the iterator is not read until Next returns false.
+func QueryAnyIter(ctx context.Context, conn Tx, query string, args ...any) (*AnyIter, error) {
+	rows, err := conn.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("query executing: %w", err)
+	}
+	cols, err := rows.ColumnTypes()
+	if err != nil {
+		_ = rows.Close()
+		return nil, fmt.Errorf("columns describing: %w", err)
+	}
+	return &AnyIter{rows: rows, cols: cols}, nil
+}
+
+// Next prepares the next row to be read with Scan.
+// It returns false when there are no more rows or on error, see Err to distinguish both cases.
+// In both cases, the iterator is closed.
+func (it *AnyIter) Next() bool {
+	if it.closed {
+		return false
+	}
+	if !it.rows.Next() {
+		it.err = it.Close()
+		if it.err != nil {
+			return false
+		}
+		err := it.rows.Err()
+		if err != nil {
+			it.err = fmt.Errorf("rows iterating: %w", err)
+		}
+		return false
+	}
+	rs := makeRs(it.cols)
+	err := it.rows.Scan(rs...)
+	if err != nil {
+		it.err = errors.Join(fmt.Errorf("rows scanning: %w", err), it.Close())
+		return false
+	}
+	it.cur = makeAny(it.cols, rs)
+	return true
+}
+
+// Scan returns the current row.
+// It returns nil if Next has not been called or has returned false.
+func (it *AnyIter) Scan() Any {
+	return it.cur
+}
+
+// Err returns the error, if any, that was encountered during iteration.
+func (it *AnyIter) Err() error {
+	return it.err
+}
+
+// Close closes the iterator and releases its connection.
+// It is safe to call it multiple times, only the first call closes the rows.
+func (it *AnyIter) Close() error {
+	if it.closed {
+		return nil
+	}
+	it.closed = true
+	it.cur = nil
+	err := it.rows.Close()
+	if err != nil {
+		return fmt.Errorf("rows closing: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+//go:build go1.23
+
+package sqlm
+
+import "iter"
+
+// All returns an iterator over the remaining rows, to use with a range loop.
+// On error, it yields a nil Any with the error as last value.
+// The AnyIter is closed once the loop ends, even on break.
+func (it *AnyIter) All() iter.Seq2[Any, error] {
+	return func(yield func(Any, error) bool) {
+		defer func() { _ = it.Close() }()
+		for it.Next() {
+			if !yield(it.Scan(), nil) {
+				return
+			}
+		}
+		err := it.Err()
+		if err != nil {
+			yield(nil, err)
+		}
+	}
+}
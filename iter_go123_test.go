@@ -0,0 +1,40 @@
+//go:build go1.23
+
+package sqlm_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/rvflash/sqlm"
+)
+
+func TestAnyIter_All(t *testing.T) {
+	res := twoRows
+	res.err = errBoom
+	db, fake := newFakeDB(t, res)
+	it, err := sqlm.QueryAnyIter(context.Background(), db, "SELECT")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var n int
+	for m, err := range it.All() {
+		if err != nil {
+			if !errors.Is(err, errBoom) {
+				t.Errorf("unexpected error: got %v, expected %v", err, errBoom)
+			}
+			continue
+		}
+		if !m.Has("id") {
+			t.Errorf("unexpected row: %v", m)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("got %d rows, expected 2", n)
+	}
+	if got := fake.Closed(); got != 1 {
+		t.Errorf("rows must be closed once: got %d closing", got)
+	}
+}
@@ -0,0 +1,98 @@
+package sqlm_test
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/rvflash/sqlm"
+)
+
+var twoRows = fakeResult{
+	columns: []fakeColumn{{name: "id"}},
+	values:  [][]driver.Value{{int64(1)}, {int64(2)}},
+}
+
+func TestAnyIter_Exhausted(t *testing.T) {
+	db, fake := newFakeDB(t, twoRows)
+	it, err := sqlm.QueryAnyIter(context.Background(), db, "SELECT")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var ids []int64
+	for it.Next() {
+		id, err := it.Scan().Int("id")
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) != 2 || ids[0] != 1 || ids[1] != 2 {
+		t.Errorf("unexpected rows: %v", ids)
+	}
+	if err = it.Err(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got := fake.Closed(); got != 1 {
+		t.Errorf("rows must be closed once exhausted: got %d closing", got)
+	}
+	if err = it.Close(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if got := fake.Closed(); got != 1 {
+		t.Errorf("rows must be closed once: got %d closing", got)
+	}
+}
+
+func TestAnyIter_Close(t *testing.T) {
+	db, fake := newFakeDB(t, twoRows)
+	it, err := sqlm.QueryAnyIter(context.Background(), db, "SELECT")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !it.Next() {
+		t.Fatalf("expected a row: %v", it.Err())
+	}
+	for i := 0; i < 2; i++ {
+		if err = it.Close(); err != nil {
+			t.Errorf("unexpected error: %v", err)
+		}
+	}
+	if got := fake.Closed(); got != 1 {
+		t.Errorf("rows must be closed once: got %d closing", got)
+	}
+	if it.Next() {
+		t.Error("no row expected once closed")
+	}
+	if it.Scan() != nil {
+		t.Errorf("no row expected once closed: got %v", it.Scan())
+	}
+}
+
+func TestAnyIter_Err(t *testing.T) {
+	res := twoRows
+	res.err = errBoom
+	db, fake := newFakeDB(t, res)
+	it, err := sqlm.QueryAnyIter(context.Background(), db, "SELECT")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	var n int
+	for it.Next() {
+		n++
+	}
+	if n != 2 {
+		t.Errorf("got %d rows, expected 2", n)
+	}
+	if err = it.Err(); !errors.Is(err, errBoom) {
+		t.Errorf("unexpected error: got %v, expected %v", err, errBoom)
+	}
+	if got := fake.Closed(); got != 1 {
+		t.Errorf("rows must be closed on error: got %d closing", got)
+	}
+	_, err = sqlm.QueryAnyRows(context.Background(), db, "SELECT")
+	if !errors.Is(err, errBoom) {
+		t.Errorf("unexpected error: got %v, expected %v", err, errBoom)
+	}
+}
@@ -0,0 +1,193 @@
+package sqlm
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// TagName is the name of the struct tag used to map a column to a field.
+const TagName = "db"
+
+// ErrNotStruct is returned when the destination type is not a struct.
+var ErrNotStruct = errors.New("not a struct")
+
+// QueryStruct executes a query that is expected to return at most one row, so one T.
+// The args are for any placeholder parameters in the query.
+// Columns are mapped to the fields of T using the db tag or, without tag,
+// a case-insensitive match on the field name. Unmapped columns are ignored.
+// If no row is returned, it returns sql.ErrNoRows.
+func QueryStruct[T any](ctx context.Context, conn Tx, query string, args ...any) (T, error) {
+	var res T
+	fields, err := structFields(reflect.TypeOf(res))
+	if err != nil {
+		return res, err
+	}
+	m, err := QueryAny(ctx, conn, query, args...)
+	if err != nil {
+		return res, err
+	}
+	err = scanStruct(m, reflect.ValueOf(&res).Elem(), fields)
+	if err != nil {
+		return res, err
+	}
+	return res, nil
+}
+
+// QueryStructRows executes a query that returns rows as slice of T, typically a SELECT.
+// The args are for any placeholder parameters in the query.
+// See QueryStruct for the mapping rules of the columns.
+func QueryStructRows[T any](ctx context.Context, conn Tx, query string, args ...any) ([]T, error) {
+	fields, err := structFields(reflect.TypeOf((*T)(nil)).Elem())
+	if err != nil {
+		return nil, err
+	}
+	rows, err := QueryAnyRows(ctx, conn, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]T, len(rows))
+	for pos, m := range rows {
+		err = scanStruct(m, reflect.ValueOf(&res[pos]).Elem(), fields)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func scanStruct(m Any, rv reflect.Value, fields map[string]int) error {
+	for name, v := range m {
+		pos, ok := fields[name]
+		if !ok {
+			pos, ok = fields[strings.ToLower(name)]
+		}
+		if !ok {
+			continue
+		}
+		err := setField(rv.Field(pos), v)
+		if err != nil {
+			return fmt.Errorf("struct mapping: %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// structFields returns the index of each exported field by column name.
+// Tagged fields are indexed with their tag, the others with their lowercase name.
+func structFields(t reflect.Type) (map[string]int, error) {
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("struct mapping: %w: %v", ErrNotStruct, t)
+	}
+	var (
+		res    = make(map[string]int, t.NumField())
+		tagged = make(map[string]struct{})
+	)
+	for pos := 0; pos < t.NumField(); pos++ {
+		f := t.Field(pos)
+		if !f.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(f.Tag.Get(TagName), ",")
+		switch name {
+		case "-":
+		case "":
+			// Tagged fields take precedence.
+			if _, ok := tagged[strings.ToLower(f.Name)]; !ok {
+				res[strings.ToLower(f.Name)] = pos
+			}
+		default:
+			res[name] = pos
+			tagged[name] = struct{}{}
+		}
+	}
+	return res, nil
+}
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+func setField(f reflect.Value, v any) error {
+	if reflect.PointerTo(f.Type()).Implements(scannerType) {
+		return f.Addr().Interface().(sql.Scanner).Scan(v)
+	}
+	if v == nil {
+		f.Set(reflect.Zero(f.Type()))
+		return nil
+	}
+	if f.Kind() == reflect.Pointer {
+		p := reflect.New(f.Type().Elem())
+		err := setField(p.Elem(), v)
+		if err != nil {
+			return err
+		}
+		f.Set(p)
+		return nil
+	}
+	switch {
+	case f.Type() == timeType:
+		t, err := toTime(v)
+		if err != nil {
+			return err
+		}
+		f.Set(reflect.ValueOf(t))
+		return nil
+	case f.Type() == bytesType:
+		b, err := toBytes(v)
+		if err != nil {
+			return err
+		}
+		f.SetBytes(append([]byte(nil), b...))
+		return nil
+	}
+	switch f.Kind() {
+	case reflect.Bool:
+		b, err := toBool(v)
+		if err != nil {
+			return err
+		}
+		f.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := toInt(v)
+		if err != nil {
+			return err
+		}
+		if f.OverflowInt(i) {
+			return fmt.Errorf("%w: %d overflows %s", ErrConversion, i, f.Type())
+		}
+		f.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := toUint(v)
+		if err != nil {
+			return err
+		}
+		if f.OverflowUint(u) {
+			return fmt.Errorf("%w: %d overflows %s", ErrConversion, u, f.Type())
+		}
+		f.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		n, err := toFloat(v)
+		if err != nil {
+			return err
+		}
+		if f.OverflowFloat(n) {
+			return fmt.Errorf("%w: %v overflows %s", ErrConversion, n, f.Type())
+		}
+		f.SetFloat(n)
+	case reflect.String:
+		f.SetString(toString(v))
+	default:
+		rv := reflect.ValueOf(v)
+		if !rv.Type().AssignableTo(f.Type()) {
+			return conversionError(v, f.Type().String())
+		}
+		f.Set(rv)
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package sqlm_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/rvflash/sqlm"
+)
+
+type user struct {
+	ID     int64 `db:"id"`
+	Name   string
+	Nick   *string
+	Note   sql.NullString
+	Big    uint64
+	Small  uint8
+	Skip   string `db:"-"`
+	secret string
+}
+
+var userColumns = []fakeColumn{
+	{name: "id"}, {name: "NAME"}, {name: "nick"}, {name: "note"},
+	{name: "big"}, {name: "small"}, {name: "skip"}, {name: "secret"}, {name: "extra"},
+}
+
+func TestQueryStruct(t *testing.T) {
+	nick := "bob"
+	for name, tc := range map[string]struct {
+		values []driver.Value
+		out    user
+		err    error
+	}{
+		"Nulls": {
+			values: []driver.Value{int64(1), "Bob", nil, nil, uint64(1<<63 + 5), int64(255), "skip", "secret", "extra"},
+			out:    user{ID: 1, Name: "Bob", Big: 1<<63 + 5, Small: 255},
+		},
+		"Values": {
+			values: []driver.Value{[]byte("2"), []byte("Bob"), "bob", "note", "5", int64(0), nil, nil, nil},
+			out:    user{ID: 2, Name: "Bob", Nick: &nick, Note: sql.NullString{String: "note", Valid: true}, Big: 5},
+		},
+		"Overflow": {
+			values: []driver.Value{int64(1), "Bob", nil, nil, int64(1), int64(256), nil, nil, nil},
+			err:    sqlm.ErrConversion,
+		},
+		"Negative": {
+			values: []driver.Value{int64(1), "Bob", nil, nil, int64(-1), int64(0), nil, nil, nil},
+			err:    sqlm.ErrConversion,
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			db, _ := newFakeDB(t, fakeResult{columns: userColumns, values: [][]driver.Value{tc.values}})
+			out, err := sqlm.QueryStruct[user](context.Background(), db, "SELECT")
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("unexpected error: got %v, expected %v", err, tc.err)
+			}
+			if tc.err == nil && !reflect.DeepEqual(out, tc.out) {
+				t.Errorf("got %+v, expected %+v", out, tc.out)
+			}
+		})
+	}
+}
+
+func TestQueryStruct_NoRows(t *testing.T) {
+	db, _ := newFakeDB(t, fakeResult{columns: userColumns})
+	_, err := sqlm.QueryStruct[user](context.Background(), db, "SELECT")
+	if !errors.Is(err, sql.ErrNoRows) {
+		t.Fatalf("unexpected error: got %v, expected %v", err, sql.ErrNoRows)
+	}
+}
+
+func TestQueryStruct_NotStruct(t *testing.T) {
+	db, fake := newFakeDB(t, fakeResult{columns: userColumns})
+	_, err := sqlm.QueryStruct[int](context.Background(), db, "SELECT")
+	if !errors.Is(err, sqlm.ErrNotStruct) {
+		t.Fatalf("unexpected error: got %v, expected %v", err, sqlm.ErrNotStruct)
+	}
+	if len(fake.Log()) != 0 {
+		t.Errorf("unexpected query: %q", fake.Log())
+	}
+}
+
+func TestQueryStructRows(t *testing.T) {
+	db, _ := newFakeDB(t, fakeResult{
+		columns: []fakeColumn{{name: "id"}, {name: "name"}},
+		values:  [][]driver.Value{{int64(1), "a"}, {int64(2), "b"}},
+	})
+	out, err := sqlm.QueryStructRows[user](context.Background(), db, "SELECT")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if exp := []user{{ID: 1, Name: "a"}, {ID: 2, Name: "b"}}; !reflect.DeepEqual(out, exp) {
+		t.Errorf("got %+v, expected %+v", out, exp)
+	}
+}
@@ -5,8 +5,39 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+)
+
+// List of MySQL error numbers considered as retryable.
+const (
+	// MySQLLockWaitTimeout is the MySQL error number of a lock wait timeout.
+	MySQLLockWaitTimeout uint16 = 1205
+	// MySQLDeadlock is the MySQL error number of a deadlock found when trying to get lock.
+	MySQLDeadlock uint16 = 1213
 )
 
+// ErrTxNotSupported is returned when a connection can neither begin a transaction nor is one.
+var ErrTxNotSupported = errors.New("transaction not supported")
+
+// retryable lists additional predicates used by IsRetryable to classify an error as retryable.
+var retryable struct {
+	sync.RWMutex
+	list []func(err error) bool
+}
+
+// RegisterRetryable adds a predicate used by IsRetryable to classify an error as retryable.
+// It allows to extend the default set and is safe for concurrent use.
+func RegisterRetryable(f func(err error) bool) {
+	retryable.Lock()
+	defer retryable.Unlock()
+	retryable.list = append(retryable.list, f)
+}
+
 // BeginTx represents the database connection who handles transactions.
 type BeginTx interface {
 	// BeginTx starts a transaction.
@@ -30,10 +61,18 @@ type Tx interface {
 
 // WithTx creates a new transaction and handles commit/rollback based on the returned error.
 // It uses db to handle the transaction and ctx to isolate it.
+// The transaction uses the serializable isolation level.
 // See https://golang.org/doc/go1.8#database_sql.
-func WithTx(ctx context.Context, db BeginTx, f func(Tx) error) (err error) {
+func WithTx(ctx context.Context, db BeginTx, f func(Tx) error) error {
+	return WithTxOpts(ctx, db, &sql.TxOptions{Isolation: sql.LevelSerializable}, f)
+}
+
+// WithTxOpts creates a new transaction with the given options and handles commit/rollback
+// based on the returned error. If opts is nil, the driver's default options are used.
+// It uses db to handle the transaction and ctx to isolate it.
+func WithTxOpts(ctx context.Context, db BeginTx, opts *sql.TxOptions, f func(Tx) error) (err error) {
 	var tx *sql.Tx
-	tx, err = db.BeginTx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	tx, err = db.BeginTx(ctx, opts)
 	if err != nil {
 		return err
 	}
@@ -52,3 +91,119 @@ func WithTx(ctx context.Context, db BeginTx, f func(Tx) error) (err error) {
 	}()
 	return f(tx)
 }
+
+// WithTxRetry behaves as WithTx but re-runs f from scratch on a new transaction
+// while the returned error is retryable, up to attempts times.
+// It waits for backoff between each attempt and stops as soon as ctx is done.
+// If attempts is lower than or equal to 1, f is run once.
+// See IsRetryable to know which errors are retried.
+func WithTxRetry(ctx context.Context, db BeginTx, attempts int, backoff time.Duration, f func(Tx) error) error {
+	for i := 1; ; i++ {
+		err := WithTx(ctx, db, f)
+		if err == nil || i >= attempts || !IsRetryable(err) {
+			return err
+		}
+		t := time.NewTimer(backoff)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-t.C:
+		}
+	}
+}
+
+// IsRetryable returns true if the error is a transient one, such as a MySQL deadlock
+// or a lock wait timeout, or if one of the registered predicates recognizes it.
+// See RegisterRetryable.
+func IsRetryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	var me *mysql.MySQLError
+	if errors.As(err, &me) && (me.Number == MySQLDeadlock || me.Number == MySQLLockWaitTimeout) {
+		return true
+	}
+	// Predicates are called without the lock, so they can use IsRetryable or RegisterRetryable.
+	retryable.RLock()
+	list := retryable.list
+	retryable.RUnlock()
+	for _, f := range list {
+		if f(err) {
+			return true
+		}
+	}
+	return false
+}
+
+// WithNestedTx runs f inside a transaction and handles commit/rollback based on the returned error.
+// If conn is already a transaction, f runs inside a new savepoint of it:
+// the savepoint is released on success and rolled back to on error or panic.
+// Otherwise, if conn can begin a transaction, it behaves as WithTx.
+// The Tx given to f can be passed to WithNestedTx to nest another block.
+func WithNestedTx(ctx context.Context, conn Tx, f func(Tx) error) error {
+	switch tx := conn.(type) {
+	case *savepointTx:
+		return withSavepoint(ctx, tx, f)
+	case *sql.Tx:
+		return withSavepoint(ctx, newSavepointTx(tx), f)
+	case BeginTx:
+		return WithTx(ctx, tx, func(tx Tx) error {
+			return f(newSavepointTx(tx.(*sql.Tx)))
+		})
+	default:
+		return fmt.Errorf("%w: %T", ErrTxNotSupported, conn)
+	}
+}
+
+// savepointIDs identifies each savepointTx, so two of them around the same
+// *sql.Tx never share a savepoint name.
+var savepointIDs atomic.Int64
+
+// savepointTx is a transaction counting its savepoints to give them unique names.
+type savepointTx struct {
+	*sql.Tx
+	id  int64
+	seq atomic.Int64
+}
+
+func newSavepointTx(tx *sql.Tx) *savepointTx {
+	return &savepointTx{Tx: tx, id: savepointIDs.Add(1)}
+}
+
+func (tx *savepointTx) savepoint() string {
+	return "sp_" + strconv.FormatInt(tx.id, 10) + "_" + strconv.FormatInt(tx.seq.Add(1), 10)
+}
+
+func withSavepoint(ctx context.Context, tx *savepointTx, f func(Tx) error) (err error) {
+	name := tx.savepoint()
+	_, err = tx.ExecContext(ctx, "SAVEPOINT "+name)
+	if err != nil {
+		return fmt.Errorf("savepoint creating: %w", err)
+	}
+	defer func() {
+		r := recover()
+		switch {
+		case r != nil:
+			// Panic? No. Rollbacks to the savepoint then panics.
+			err = errors.Join(fmt.Errorf("%v", r), rollbackTo(ctx, tx, name))
+			panic(err)
+		case err != nil:
+			err = errors.Join(err, rollbackTo(ctx, tx, name))
+		default:
+			_, err = tx.ExecContext(ctx, "RELEASE SAVEPOINT "+name)
+			if err != nil {
+				err = fmt.Errorf("savepoint releasing: %w", err)
+			}
+		}
+	}()
+	return f(tx)
+}
+
+func rollbackTo(ctx context.Context, tx Tx, name string) error {
+	_, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT "+name)
+	if err != nil {
+		return fmt.Errorf("savepoint rolling back: %w", err)
+	}
+	return nil
+}
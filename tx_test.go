@@ -0,0 +1,326 @@
+package sqlm_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/rvflash/sqlm"
+)
+
+var errBoom = errors.New("boom")
+
+// beginTxStub records the options of each transaction before starting it on db.
+type beginTxStub struct {
+	db   *sql.DB
+	opts []*sql.TxOptions
+}
+
+func (s *beginTxStub) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	s.opts = append(s.opts, opts)
+	return s.db.BeginTx(ctx, opts)
+}
+
+func TestWithTxOpts(t *testing.T) {
+	for name, tc := range map[string]struct {
+		run    func(ctx context.Context, db sqlm.BeginTx, f func(sqlm.Tx) error) error
+		opts   *sql.TxOptions
+		txOpts driver.TxOptions
+	}{
+		"Default": {
+			run:    sqlm.WithTx,
+			opts:   &sql.TxOptions{Isolation: sql.LevelSerializable},
+			txOpts: driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelSerializable)},
+		},
+		"ReadOnly": {
+			run: func(ctx context.Context, db sqlm.BeginTx, f func(sqlm.Tx) error) error {
+				return sqlm.WithTxOpts(ctx, db, &sql.TxOptions{Isolation: sql.LevelReadCommitted, ReadOnly: true}, f)
+			},
+			opts:   &sql.TxOptions{Isolation: sql.LevelReadCommitted, ReadOnly: true},
+			txOpts: driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelReadCommitted), ReadOnly: true},
+		},
+		"Nil": {
+			run: func(ctx context.Context, db sqlm.BeginTx, f func(sqlm.Tx) error) error {
+				return sqlm.WithTxOpts(ctx, db, nil, f)
+			},
+		},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			db, fake := newFakeDB(t, fakeResult{})
+			stub := &beginTxStub{db: db}
+			err := tc.run(context.Background(), stub, func(sqlm.Tx) error { return nil })
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(stub.opts) != 1 || !reflect.DeepEqual(stub.opts[0], tc.opts) {
+				t.Errorf("options mismatch: got %+v, expected %+v", stub.opts, tc.opts)
+			}
+			if got := fake.TxOptions(); len(got) != 1 || got[0] != tc.txOpts {
+				t.Errorf("driver options mismatch: got %+v, expected %+v", got, tc.txOpts)
+			}
+			if got, exp := fake.Log(), []string{"BEGIN", "COMMIT"}; !reflect.DeepEqual(got, exp) {
+				t.Errorf("log mismatch: got %q, expected %q", got, exp)
+			}
+		})
+	}
+}
+
+func TestWithTxOpts_Rollback(t *testing.T) {
+	db, fake := newFakeDB(t, fakeResult{})
+	err := sqlm.WithTxOpts(context.Background(), db, nil, func(sqlm.Tx) error { return errBoom })
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("unexpected error: got %v, expected %v", err, errBoom)
+	}
+	if got, exp := fake.Log(), []string{"BEGIN", "ROLLBACK"}; !reflect.DeepEqual(got, exp) {
+		t.Errorf("log mismatch: got %q, expected %q", got, exp)
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	errCustom := errors.New("custom")
+	// A predicate may use the package without dead lock.
+	sqlm.RegisterRetryable(func(err error) bool {
+		if errors.Is(err, errCustom) {
+			sqlm.RegisterRetryable(func(error) bool { return false })
+		}
+		return false
+	})
+	sqlm.RegisterRetryable(func(err error) bool { return errors.Is(err, errCustom) })
+	for name, tc := range map[string]struct {
+		in  error
+		out bool
+	}{
+		"Nil":         {},
+		"Default":     {in: errBoom},
+		"Deadlock":    {in: &mysql.MySQLError{Number: sqlm.MySQLDeadlock}, out: true},
+		"LockTimeout": {in: &mysql.MySQLError{Number: sqlm.MySQLLockWaitTimeout}, out: true},
+		"Wrapped":     {in: fmt.Errorf("oops: %w", &mysql.MySQLError{Number: sqlm.MySQLDeadlock}), out: true},
+		"Joined":      {in: errors.Join(errBoom, &mysql.MySQLError{Number: sqlm.MySQLDeadlock}), out: true},
+		"OtherMySQL":  {in: &mysql.MySQLError{Number: 1062}},
+		"Registered":  {in: fmt.Errorf("oops: %w", errCustom), out: true},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			if got := sqlm.IsRetryable(tc.in); got != tc.out {
+				t.Errorf("got %v, expected %v", got, tc.out)
+			}
+		})
+	}
+}
+
+func TestWithTxRetry(t *testing.T) {
+	deadlock := &mysql.MySQLError{Number: sqlm.MySQLDeadlock}
+	for name, tc := range map[string]struct {
+		attempts int
+		fails    int
+		err      error
+		calls    int
+	}{
+		"OK":           {attempts: 3, calls: 1},
+		"Retried":      {attempts: 3, fails: 2, err: deadlock, calls: 3},
+		"Exhausted":    {attempts: 3, fails: 5, err: deadlock, calls: 3},
+		"NotRetryable": {attempts: 3, fails: 5, err: errBoom, calls: 1},
+		"Zero":         {attempts: 0, fails: 5, err: deadlock, calls: 1},
+	} {
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			db, fake := newFakeDB(t, fakeResult{})
+			stub := &beginTxStub{db: db}
+			var (
+				calls int
+				txs   = make(map[sqlm.Tx]struct{})
+			)
+			err := sqlm.WithTxRetry(context.Background(), stub, tc.attempts, time.Millisecond, func(tx sqlm.Tx) error {
+				calls++
+				txs[tx] = struct{}{}
+				if calls <= tc.fails {
+					return tc.err
+				}
+				return nil
+			})
+			if tc.fails >= tc.calls && !errors.Is(err, tc.err) {
+				t.Fatalf("unexpected error: got %v, expected %v", err, tc.err)
+			}
+			if tc.fails < tc.calls && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if calls != tc.calls || len(stub.opts) != tc.calls || len(txs) != tc.calls {
+				t.Errorf("got %d calls on %d transactions (%d begun), expected %d", calls, len(txs), len(stub.opts), tc.calls)
+			}
+			if got := len(fake.TxOptions()); got != tc.calls {
+				t.Errorf("got %d transactions started by the driver, expected %d", got, tc.calls)
+			}
+		})
+	}
+}
+
+func TestWithTxRetry_Panic(t *testing.T) {
+	db, fake := newFakeDB(t, fakeResult{})
+	stub := &beginTxStub{db: db}
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic")
+		}
+		if len(stub.opts) != 1 {
+			t.Errorf("got %d transactions, expected 1", len(stub.opts))
+		}
+		if got, exp := fake.Log(), []string{"BEGIN", "ROLLBACK"}; !reflect.DeepEqual(got, exp) {
+			t.Errorf("log mismatch: got %q, expected %q", got, exp)
+		}
+	}()
+	_ = sqlm.WithTxRetry(context.Background(), stub, 3, time.Millisecond, func(sqlm.Tx) error {
+		panic("oops")
+	})
+}
+
+func TestWithTxRetry_Canceled(t *testing.T) {
+	db, _ := newFakeDB(t, fakeResult{})
+	stub := &beginTxStub{db: db}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var calls int
+	err := sqlm.WithTxRetry(ctx, stub, 3, time.Hour, func(sqlm.Tx) error {
+		calls++
+		cancel()
+		return &mysql.MySQLError{Number: sqlm.MySQLLockWaitTimeout}
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("unexpected error: got %v, expected %v", err, context.Canceled)
+	}
+	if calls != 1 || len(stub.opts) != 1 {
+		t.Errorf("got %d calls on %d transactions, expected 1", calls, len(stub.opts))
+	}
+}
+
+// savepointLog returns the log with the savepoint names replaced by sp_1, sp_2, etc.
+// in their order of appearance, since the real names are only unique.
+func savepointLog(log []string) []string {
+	names := make(map[string]string)
+	res := make([]string, len(log))
+	for pos, q := range log {
+		i := strings.LastIndex(q, "SAVEPOINT ")
+		if i < 0 {
+			res[pos] = q
+			continue
+		}
+		i += len("SAVEPOINT ")
+		name, ok := names[q[i:]]
+		if !ok {
+			name = fmt.Sprintf("sp_%d", len(names)+1)
+			names[q[i:]] = name
+		}
+		res[pos] = q[:i] + name
+	}
+	return res
+}
+
+func TestWithNestedTx(t *testing.T) {
+	db, fake := newFakeDB(t, fakeResult{})
+	ctx := context.Background()
+	err := sqlm.WithTx(ctx, db, func(tx sqlm.Tx) error {
+		err := sqlm.WithNestedTx(ctx, tx, func(tx sqlm.Tx) error {
+			err := sqlm.WithNestedTx(ctx, tx, func(sqlm.Tx) error { return nil })
+			if err != nil {
+				return err
+			}
+			return sqlm.WithNestedTx(ctx, tx, func(sqlm.Tx) error { return errBoom })
+		})
+		if !errors.Is(err, errBoom) {
+			t.Errorf("unexpected error: got %v, expected %v", err, errBoom)
+		}
+		return sqlm.WithNestedTx(ctx, tx, func(sqlm.Tx) error { return nil })
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := []string{
+		"BEGIN",
+		"SAVEPOINT sp_1",
+		"SAVEPOINT sp_2",
+		"RELEASE SAVEPOINT sp_2",
+		"SAVEPOINT sp_3",
+		"ROLLBACK TO SAVEPOINT sp_3",
+		"ROLLBACK TO SAVEPOINT sp_1",
+		"SAVEPOINT sp_4",
+		"RELEASE SAVEPOINT sp_4",
+		"COMMIT",
+	}
+	if got := savepointLog(fake.Log()); !reflect.DeepEqual(got, exp) {
+		t.Errorf("log mismatch: got %q, expected %q", got, exp)
+	}
+}
+
+func TestWithNestedTx_RawTx(t *testing.T) {
+	db, fake := newFakeDB(t, fakeResult{})
+	ctx := context.Background()
+	err := sqlm.WithTx(ctx, db, func(tx sqlm.Tx) error {
+		return sqlm.WithNestedTx(ctx, tx, func(sqlm.Tx) error {
+			// Nests with the outer *sql.Tx, not the one given.
+			return sqlm.WithNestedTx(ctx, tx, func(sqlm.Tx) error { return errBoom })
+		})
+	})
+	if !errors.Is(err, errBoom) {
+		t.Fatalf("unexpected error: got %v, expected %v", err, errBoom)
+	}
+	exp := []string{
+		"BEGIN",
+		"SAVEPOINT sp_1",
+		"SAVEPOINT sp_2",
+		"ROLLBACK TO SAVEPOINT sp_2",
+		"ROLLBACK TO SAVEPOINT sp_1",
+		"ROLLBACK",
+	}
+	if got := savepointLog(fake.Log()); !reflect.DeepEqual(got, exp) {
+		t.Errorf("log mismatch: got %q, expected %q", got, exp)
+	}
+}
+
+func TestWithNestedTx_TopLevel(t *testing.T) {
+	db, fake := newFakeDB(t, fakeResult{})
+	ctx := context.Background()
+	err := sqlm.WithNestedTx(ctx, db, func(tx sqlm.Tx) error {
+		return sqlm.WithNestedTx(ctx, tx, func(sqlm.Tx) error { return nil })
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	exp := []string{"BEGIN", "SAVEPOINT sp_1", "RELEASE SAVEPOINT sp_1", "COMMIT"}
+	if got := savepointLog(fake.Log()); !reflect.DeepEqual(got, exp) {
+		t.Errorf("log mismatch: got %q, expected %q", got, exp)
+	}
+}
+
+func TestWithNestedTx_Panic(t *testing.T) {
+	db, fake := newFakeDB(t, fakeResult{})
+	ctx := context.Background()
+	defer func() {
+		if r := recover(); r == nil {
+			t.Fatal("expected panic")
+		}
+		exp := []string{"BEGIN", "SAVEPOINT sp_1", "ROLLBACK TO SAVEPOINT sp_1", "ROLLBACK"}
+		if got := savepointLog(fake.Log()); !reflect.DeepEqual(got, exp) {
+			t.Errorf("log mismatch: got %q, expected %q", got, exp)
+		}
+	}()
+	_ = sqlm.WithTx(ctx, db, func(tx sqlm.Tx) error {
+		return sqlm.WithNestedTx(ctx, tx, func(sqlm.Tx) error {
+			panic("oops")
+		})
+	})
+}
+
+func TestWithNestedTx_NotSupported(t *testing.T) {
+	type conn struct{ sqlm.Tx }
+	err := sqlm.WithNestedTx(context.Background(), conn{}, func(sqlm.Tx) error { return nil })
+	if !errors.Is(err, sqlm.ErrTxNotSupported) {
+		t.Fatalf("unexpected error: got %v, expected %v", err, sqlm.ErrTxNotSupported)
+	}
+}